@@ -0,0 +1,54 @@
+// Package guarded pairs nbmutex.Mutex with the value it protects, so that
+// the protected value can't be touched without holding the lock.
+package guarded
+
+import "github.com/rbranson/nbmutex"
+
+// Guarded couples a non-blocking mutex with an owned value of type T. Unlike
+// using a bare nbmutex.Mutex alongside a struct field, there's no way to
+// reach the value without going through TryLock or TryWith first.
+//
+// The zero value of Guarded[T] is a usable, unlocked guard around the zero
+// value of T. Do not copy this after first use, for the same reasons you
+// shouldn't copy an nbmutex.Mutex.
+type Guarded[T any] struct {
+	mu  nbmutex.Mutex
+	val T
+}
+
+// New returns a Guarded[T] wrapping val.
+func New[T any](val T) *Guarded[T] {
+	return &Guarded[T]{val: val}
+}
+
+// TryLock attempts to acquire the guard.
+//
+// If the guard is acquired, ok will be true, val will point at the protected
+// value, and unlock will be a function that *must* be called to release the
+// guard. By convention, val should not be used again after unlock is called.
+//
+// If the guard is not acquired, ok will be false and val will be nil.
+//
+// It is safe to call unlock regardless of the return value of ok.
+func (g *Guarded[T]) TryLock() (val *T, unlock func(), ok bool) {
+	unlock, ok = g.mu.TryLock()
+	if !ok {
+		return nil, unlock, false
+	}
+	return &g.val, unlock, true
+}
+
+// TryWith runs fn with exclusive access to the protected value, returning
+// whether it ran. This is a convenience for the common case of wanting to
+// touch the value without dealing with the unlock function directly, such as
+// the occasional metrics submission use case nbmutex.Mutex targets.
+func (g *Guarded[T]) TryWith(fn func(val *T)) bool {
+	val, unlock, ok := g.TryLock()
+	if !ok {
+		return false
+	}
+	defer unlock()
+
+	fn(val)
+	return true
+}
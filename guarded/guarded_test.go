@@ -0,0 +1,114 @@
+package guarded
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGuardedTryLockSuccess(t *testing.T) {
+	g := New(42)
+
+	val, unlock, ok := g.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed on an unlocked guard")
+	}
+	defer unlock()
+
+	if *val != 42 {
+		t.Fatalf("expected val to be 42, got %d", *val)
+	}
+
+	*val = 7
+}
+
+func TestGuardedTryLockContended(t *testing.T) {
+	g := New(0)
+
+	_, unlock, ok := g.TryLock()
+	if !ok {
+		t.Fatal("expected first TryLock to succeed")
+	}
+
+	if _, _, ok := g.TryLock(); ok {
+		t.Fatal("expected TryLock to fail while the guard is already held")
+	}
+
+	unlock()
+
+	if _, unlock, ok := g.TryLock(); !ok {
+		t.Fatal("expected TryLock to succeed after the guard was unlocked")
+	} else {
+		unlock()
+	}
+}
+
+func TestGuardedTryWithRuns(t *testing.T) {
+	g := New(1)
+
+	ran := g.TryWith(func(val *int) {
+		*val += 1
+	})
+	if !ran {
+		t.Fatal("expected TryWith to run on an unlocked guard")
+	}
+
+	g.TryWith(func(val *int) {
+		if *val != 2 {
+			t.Fatalf("expected val to be 2, got %d", *val)
+		}
+	})
+}
+
+func TestGuardedTryWithContended(t *testing.T) {
+	g := New(0)
+
+	_, unlock, ok := g.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed")
+	}
+	defer unlock()
+
+	if ran := g.TryWith(func(*int) {
+		t.Fatal("fn must not run while the guard is already held")
+	}); ran {
+		t.Fatal("expected TryWith to report false while the guard is already held")
+	}
+}
+
+// TestGuardedExclusiveAccess proves the whole point of the wrapper: many
+// goroutines hammering TryWith concurrently never observe the protected
+// value at the same time as another goroutine.
+func TestGuardedExclusiveAccess(t *testing.T) {
+	g := New(0)
+
+	const goroutines = 32
+	const iterations = 500
+
+	var inCriticalSection int32
+	var successes int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				g.TryWith(func(val *int) {
+					if !atomic.CompareAndSwapInt32(&inCriticalSection, 0, 1) {
+						t.Error("two goroutines observed the guard held simultaneously")
+						return
+					}
+					*val++
+					atomic.StoreInt32(&inCriticalSection, 0)
+					atomic.AddInt32(&successes, 1)
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if successes == 0 {
+		t.Fatal("expected at least one TryWith to succeed under contention")
+	}
+}
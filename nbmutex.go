@@ -1,6 +1,13 @@
 package nbmutex
 
-import "sync/atomic"
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
 
 // Mutex is a non-blocking mutual exclusion lock. The zero value for a Mutex is
 // an unlocked mutex. Do not copy this after first use or you'll have a bad
@@ -14,14 +21,57 @@ import "sync/atomic"
 // potentially blocks for an extended period of time, such as making a request
 // across the network. Code that periodically submits metrics to a remote
 // system would benefit from this, for example.
+//
+// Mutex also offers Lock and LockUntil for callers that are willing to wait,
+// up to a context deadline or a fixed point in time respectively, rather than
+// give up immediately like TryLock does.
 type Mutex struct {
-	// cnt tracks the number of goroutines in the critical section, which should
-	// only ever be 1.
+	// cnt is 0 when the mutex is free and 1 when it's held. The zero value
+	// of Mutex is therefore already a valid, unlocked mutex.
 	cnt int32
+
+	// waiters counts the goroutines currently parked (or about to park) in
+	// Lock/LockUntil. unlock only needs to signal ch when this is nonzero;
+	// tracking it separately from cnt, rather than folding "has waiters"
+	// into cnt as a third state, is what lets every waiter be woken in turn
+	// instead of silently forgotten when one of them re-acquires the mutex
+	// via the plain CAS fast path.
+	waiters int32
+
+	chanOnce sync.Once
+	ch       chan struct{}
 }
 
 var emptyFunc = func() {}
 
+// waitChan lazily creates the channel used to wake blocked waiters. It's
+// buffered by one so that unlock can hand off a wakeup without blocking
+// even if no one is receiving yet.
+func (m *Mutex) waitChan() chan struct{} {
+	m.chanOnce.Do(func() {
+		m.ch = make(chan struct{}, 1)
+	})
+	return m.ch
+}
+
+// unlock releases the mutex, waking a blocked waiter if one is parked. It's
+// shared by TryLock, TryLockSpin, Lock, and LockUntil.
+func (m *Mutex) unlock() {
+	raceRelease(unsafe.Pointer(&m.cnt))
+
+	if !atomic.CompareAndSwapInt32(&m.cnt, 1, 0) {
+		// if this happens something is very broken with the implementation
+		panic("unlock detected inconsistency")
+	}
+
+	if atomic.LoadInt32(&m.waiters) > 0 {
+		select {
+		case m.waitChan() <- struct{}{}:
+		default:
+		}
+	}
+}
+
 // TryLock attempts to acquire the mutex.
 //
 // If the mutex is acquired, ok will be true and unlock will be a function that
@@ -36,11 +86,111 @@ func (m *Mutex) TryLock() (unlock func(), ok bool) {
 		return emptyFunc, false
 	}
 
-	unlocker := func() {
-		if !atomic.CompareAndSwapInt32(&m.cnt, 1, 0) {
-			// if this happens something is very broken with the implementation
-			panic("unlock detected inconsistency")
+	raceAcquire(unsafe.Pointer(&m.cnt))
+	return m.unlock, true
+}
+
+// TryLockSpin attempts to acquire the mutex, actively spinning for up to
+// maxSpins iterations before giving up. This trades CPU time for a better
+// chance of acquiring the lock without the caller falling back to a
+// blocking primitive, which is worthwhile when critical sections are very
+// short (the classic "occasional contention" case this package targets).
+//
+// Spinning only happens when GOMAXPROCS > 1, since on a single processor a
+// spinning goroutine can only burn the time slice that the lock holder
+// needs to finish and release it; in that case TryLockSpin degrades to a
+// single CAS attempt, identical to TryLock.
+//
+// The same contract as TryLock applies to the returned unlock and ok.
+func (m *Mutex) TryLockSpin(maxSpins int) (unlock func(), ok bool) {
+	if unlock, ok = m.TryLock(); ok {
+		return unlock, true
+	}
+
+	if maxSpins <= 0 || runtime.GOMAXPROCS(0) <= 1 {
+		return emptyFunc, false
+	}
+
+	for i := 0; i < maxSpins; i++ {
+		runtime.Gosched()
+
+		if unlock, ok = m.TryLock(); ok {
+			return unlock, true
+		}
+	}
+
+	return emptyFunc, false
+}
+
+// Lock acquires the mutex, blocking until it succeeds or ctx is done.
+//
+// If the mutex is acquired, err will be nil and unlock will be a function
+// that *must* be called to release the mutex.
+//
+// If ctx is done before the mutex is acquired, err will be ctx.Err() and
+// unlock will be a no-op; no wakeup intended for another waiter is lost in
+// this case.
+func (m *Mutex) Lock(ctx context.Context) (unlock func(), err error) {
+	if unlock, ok := m.TryLock(); ok {
+		return unlock, nil
+	}
+
+	// Register as a waiter *before* the retry below, so that any unlock
+	// happening from here on sees waiters > 0 and signals ch. Any unlock
+	// that happened before this point already left cnt free, and the retry
+	// picks that up directly - closing the race window without ever losing
+	// a wakeup.
+	atomic.AddInt32(&m.waiters, 1)
+	defer atomic.AddInt32(&m.waiters, -1)
+
+	ch := m.waitChan()
+	for {
+		if unlock, ok := m.TryLock(); ok {
+			return unlock, nil
+		}
+
+		select {
+		case <-ch:
+			// woken by an unlock; loop around and try to claim the mutex.
+		case <-ctx.Done():
+			return emptyFunc, ctx.Err()
+		}
+	}
+}
+
+// LockUntil acquires the mutex, blocking until it succeeds or deadline
+// passes.
+//
+// If the mutex is acquired, ok will be true and unlock will be a function
+// that *must* be called to release the mutex.
+//
+// If deadline passes before the mutex is acquired, ok will be false and
+// unlock will be a no-op; no wakeup intended for another waiter is lost in
+// this case.
+func (m *Mutex) LockUntil(deadline time.Time) (unlock func(), ok bool) {
+	if unlock, ok := m.TryLock(); ok {
+		return unlock, true
+	}
+
+	// See the comment in Lock: registering before the retry is what keeps a
+	// concurrent unlock from being missed.
+	atomic.AddInt32(&m.waiters, 1)
+	defer atomic.AddInt32(&m.waiters, -1)
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	ch := m.waitChan()
+	for {
+		if unlock, ok := m.TryLock(); ok {
+			return unlock, true
+		}
+
+		select {
+		case <-ch:
+			// woken by an unlock; loop around and try to claim the mutex.
+		case <-timer.C:
+			return emptyFunc, false
 		}
 	}
-	return unlocker, true
 }
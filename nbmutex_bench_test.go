@@ -0,0 +1,36 @@
+package nbmutex
+
+import (
+	"testing"
+)
+
+// BenchmarkTryLockContended measures plain TryLock under short-hold
+// contention: every goroutine immediately retries on failure.
+func BenchmarkTryLockContended(b *testing.B) {
+	var m Mutex
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			unlock, ok := m.TryLock()
+			if ok {
+				unlock()
+			}
+		}
+	})
+}
+
+// BenchmarkTryLockSpinContended measures TryLockSpin under the same
+// short-hold contention, giving each goroutine a bounded number of spins
+// before it gives up.
+func BenchmarkTryLockSpinContended(b *testing.B) {
+	var m Mutex
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			unlock, ok := m.TryLockSpin(16)
+			if ok {
+				unlock()
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package nbmutex
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTryLockSpinUncontended(t *testing.T) {
+	var m Mutex
+
+	unlock, ok := m.TryLockSpin(16)
+	if !ok {
+		t.Fatal("expected TryLockSpin to succeed on an unlocked mutex")
+	}
+	unlock()
+}
+
+func TestTryLockSpinAcquiresOnceFreedMidSpin(t *testing.T) {
+	if runtime.GOMAXPROCS(0) <= 1 {
+		t.Skip("spinning is disabled when GOMAXPROCS <= 1")
+	}
+
+	var m Mutex
+
+	holdUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected initial TryLock to succeed")
+	}
+
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		holdUnlock()
+	}()
+
+	unlock, ok := m.TryLockSpin(1_000_000)
+	if !ok {
+		t.Fatal("expected TryLockSpin to eventually acquire the mutex once it freed up")
+	}
+	unlock()
+}
+
+func TestTryLockSpinGivesUpAfterMaxSpins(t *testing.T) {
+	var m Mutex
+
+	holdUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected initial TryLock to succeed")
+	}
+	defer holdUnlock()
+
+	if _, ok := m.TryLockSpin(16); ok {
+		t.Fatal("expected TryLockSpin to fail when the mutex stays held for all spins")
+	}
+}
+
+func TestTryLockSpinDegradesToSingleAttempt(t *testing.T) {
+	var m Mutex
+
+	holdUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected initial TryLock to succeed")
+	}
+	defer holdUnlock()
+
+	// maxSpins <= 0 must behave like a single TryLock attempt, regardless
+	// of GOMAXPROCS.
+	if _, ok := m.TryLockSpin(0); ok {
+		t.Fatal("expected TryLockSpin(0) to fail while the mutex is held")
+	}
+	if _, ok := m.TryLockSpin(-1); ok {
+		t.Fatal("expected TryLockSpin(-1) to fail while the mutex is held")
+	}
+}
+
+func TestTryLockSpinDegradesOnSingleProcessor(t *testing.T) {
+	prev := runtime.GOMAXPROCS(1)
+	defer runtime.GOMAXPROCS(prev)
+
+	var m Mutex
+
+	holdUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected initial TryLock to succeed")
+	}
+	defer holdUnlock()
+
+	// with GOMAXPROCS == 1, TryLockSpin must behave like a single TryLock
+	// attempt instead of spinning.
+	if _, ok := m.TryLockSpin(1_000_000); ok {
+		t.Fatal("expected TryLockSpin to fail immediately under GOMAXPROCS == 1")
+	}
+}
@@ -0,0 +1,217 @@
+package nbmutex
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMutexLockUncontended(t *testing.T) {
+	var m Mutex
+
+	unlock, err := m.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("expected Lock to succeed, got err: %v", err)
+	}
+	unlock()
+
+	if _, ok := m.TryLock(); !ok {
+		t.Fatal("expected TryLock to succeed after Lock/unlock")
+	}
+}
+
+func TestMutexLockBlocksUntilUnlock(t *testing.T) {
+	var m Mutex
+
+	firstUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected first TryLock to succeed")
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		unlock, err := m.Lock(context.Background())
+		if err != nil {
+			t.Errorf("expected Lock to succeed, got err: %v", err)
+			return
+		}
+		unlock()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("Lock returned before the mutex was released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	firstUnlock()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Lock never acquired the mutex after it was released")
+	}
+}
+
+func TestMutexLockContextCancel(t *testing.T) {
+	var m Mutex
+
+	unlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed")
+	}
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := m.Lock(ctx); err == nil {
+		t.Fatal("expected Lock to return an error once ctx was done")
+	}
+}
+
+func TestMutexLockContextCancelDoesNotLeakWakeup(t *testing.T) {
+	var m Mutex
+
+	holdUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan struct{})
+	go func() {
+		_, err := m.Lock(ctx)
+		if err == nil {
+			t.Error("expected the cancelled Lock to return an error")
+		}
+		close(cancelled)
+	}()
+
+	// give the goroutine time to register as a waiter before we cancel it.
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-cancelled
+
+	// release the original holder; any wakeup that was meant for the
+	// cancelled waiter must still be available to a later locker, not lost.
+	holdUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		unlock, err := m.Lock(context.Background())
+		if err != nil {
+			t.Errorf("expected Lock to succeed, got err: %v", err)
+			return
+		}
+		unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a later Lock never acquired the mutex; a wakeup was leaked")
+	}
+}
+
+func TestMutexLockUntilDeadlineExpires(t *testing.T) {
+	var m Mutex
+
+	unlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed")
+	}
+	defer unlock()
+
+	if _, ok := m.LockUntil(time.Now().Add(20 * time.Millisecond)); ok {
+		t.Fatal("expected LockUntil to fail once the deadline passed")
+	}
+}
+
+func TestMutexLockUntilSucceedsBeforeDeadline(t *testing.T) {
+	var m Mutex
+
+	unlock, ok := m.LockUntil(time.Now().Add(time.Second))
+	if !ok {
+		t.Fatal("expected LockUntil to succeed on an unlocked mutex")
+	}
+	unlock()
+}
+
+// TestMutexLockManyContenders exercises Lock under contention from many
+// concurrent goroutines simultaneously, so that cnt is driven through the
+// CAS acquire path far more than two-way at once: it must never drift
+// outside of {0,1}, which would otherwise surface as a panic from unlock's
+// "unlock detected inconsistency" check.
+func TestMutexLockManyContenders(t *testing.T) {
+	var m Mutex
+	var shared int
+
+	const goroutines = 32
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				unlock, err := m.Lock(context.Background())
+				if err != nil {
+					t.Errorf("unexpected Lock error: %v", err)
+					return
+				}
+				shared++
+				unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if shared != goroutines*iterations {
+		t.Fatalf("expected shared == %d, got %d", goroutines*iterations, shared)
+	}
+}
+
+// TestMutexLockOneShotWaitersAllWake is the "occasional contention" pattern
+// this package targets: a long-lived holder plus a small, bounded number of
+// one-shot Lock(ctx) callers that each try exactly once and stop, rather
+// than looping forever. Every one of them must eventually be woken once the
+// holder releases; none may be left stranded waiting on an uncontended,
+// free mutex.
+func TestMutexLockOneShotWaitersAllWake(t *testing.T) {
+	var m Mutex
+
+	holdUnlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed")
+	}
+
+	const callers = 8
+	results := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			unlock, err := m.Lock(ctx)
+			if err == nil {
+				unlock()
+			}
+			results <- err
+		}()
+	}
+
+	// give every caller a chance to park as a waiter before releasing.
+	time.Sleep(50 * time.Millisecond)
+	holdUnlock()
+
+	for i := 0; i < callers; i++ {
+		if err := <-results; err != nil {
+			t.Fatalf("caller %d never acquired the mutex: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build race
+
+package nbmutex
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+func raceAcquire(addr unsafe.Pointer) {
+	runtime.RaceAcquire(addr)
+}
+
+// raceRelease uses the "merge" variant, matching sync.Mutex, so that
+// happens-before edges from every successful unlock are visible to whichever
+// goroutine acquires the mutex next, not just the most recent one.
+func raceRelease(addr unsafe.Pointer) {
+	runtime.RaceReleaseMerge(addr)
+}
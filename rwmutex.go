@@ -0,0 +1,111 @@
+package nbmutex
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// rwMutexWriterBit is the sign bit of RWMutex.state. It's set while a writer
+// holds the lock; the remaining bits count readers currently holding it. The
+// two are mutually exclusive, which is what makes a single word enough.
+const rwMutexWriterBit = int32(-1 << 31)
+
+// RWMutex is a non-blocking, reader/writer mutual exclusion lock. Like
+// Mutex, it never blocks: TryLock and TryRLock return immediately with
+// ok == false rather than waiting for the lock to become available.
+//
+// The zero value for an RWMutex is an unlocked RWMutex. Do not copy this
+// after first use or you'll have a bad time.
+type RWMutex struct {
+	state int32
+}
+
+func (m *RWMutex) unlockWriter() {
+	raceRelease(unsafe.Pointer(&m.state))
+
+	if !atomic.CompareAndSwapInt32(&m.state, rwMutexWriterBit, 0) {
+		// if this happens something is very broken with the implementation
+		panic("unlock detected inconsistency")
+	}
+}
+
+// TryLock attempts to acquire the mutex for writing.
+//
+// If the mutex is acquired, ok will be true and unlock will be a function
+// that *must* be called to release the mutex.
+//
+// If the mutex is not acquired — because it's held by a writer or by one or
+// more readers — ok will be false.
+//
+// It is safe to call unlock regardless of the return value of ok.
+func (m *RWMutex) TryLock() (unlock func(), ok bool) {
+	if !atomic.CompareAndSwapInt32(&m.state, 0, rwMutexWriterBit) {
+		return emptyFunc, false
+	}
+
+	raceAcquire(unsafe.Pointer(&m.state))
+	return m.unlockWriter, true
+}
+
+// TryRLock attempts to acquire the mutex for reading.
+//
+// If the mutex is acquired, ok will be true and unlock.Unlock (or a
+// successful unlock.TryUpgrade) will release it.
+//
+// If the mutex is not acquired — because it's held by a writer — ok will be
+// false.
+//
+// It is safe to call unlock.Unlock regardless of the return value of ok.
+func (m *RWMutex) TryRLock() (unlock RUnlock, ok bool) {
+	for {
+		old := atomic.LoadInt32(&m.state)
+		if old < 0 {
+			return RUnlock{}, false
+		}
+
+		if atomic.CompareAndSwapInt32(&m.state, old, old+1) {
+			raceAcquire(unsafe.Pointer(&m.state))
+			return RUnlock{m: m}, true
+		}
+	}
+}
+
+// RUnlock is the unlock value returned by TryRLock. Besides releasing the
+// read lock, it can attempt to upgrade it to a write lock in place.
+type RUnlock struct {
+	m *RWMutex
+}
+
+// Unlock releases the read lock.
+func (r RUnlock) Unlock() {
+	if r.m == nil {
+		return
+	}
+	raceRelease(unsafe.Pointer(&r.m.state))
+	atomic.AddInt32(&r.m.state, -1)
+}
+
+// TryUpgrade attempts to atomically convert the held read lock into a write
+// lock, without any window in which the mutex is unlocked. It only succeeds
+// if this is the only reader currently holding the mutex; if any other
+// reader also holds it, TryUpgrade returns false and the read lock is left
+// exactly as it was, still requiring a call to Unlock.
+//
+// On success, the read lock is consumed: release the write lock with the
+// returned unlock, not with Unlock.
+func (r RUnlock) TryUpgrade() (unlock func(), ok bool) {
+	if r.m == nil {
+		return emptyFunc, false
+	}
+
+	if !atomic.CompareAndSwapInt32(&r.m.state, 1, rwMutexWriterBit) {
+		return emptyFunc, false
+	}
+
+	// The upgrade releases the read "acquire" and immediately establishes
+	// the write "acquire" in its place, with no window where the mutex
+	// reads as unheld to the race detector.
+	raceRelease(unsafe.Pointer(&r.m.state))
+	raceAcquire(unsafe.Pointer(&r.m.state))
+	return r.m.unlockWriter, true
+}
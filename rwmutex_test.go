@@ -0,0 +1,126 @@
+package nbmutex
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRWMutexTryLockExcludesReadersAndWriters(t *testing.T) {
+	var m RWMutex
+
+	unlock, ok := m.TryLock()
+	if !ok {
+		t.Fatal("expected TryLock to succeed on an unlocked RWMutex")
+	}
+
+	if _, ok := m.TryLock(); ok {
+		t.Fatal("expected TryLock to fail while a writer holds the mutex")
+	}
+	if _, ok := m.TryRLock(); ok {
+		t.Fatal("expected TryRLock to fail while a writer holds the mutex")
+	}
+
+	unlock()
+
+	if _, ok := m.TryLock(); !ok {
+		t.Fatal("expected TryLock to succeed after the writer unlocked")
+	}
+}
+
+func TestRWMutexMultipleReaders(t *testing.T) {
+	var m RWMutex
+
+	r1, ok := m.TryRLock()
+	if !ok {
+		t.Fatal("expected first TryRLock to succeed")
+	}
+	r2, ok := m.TryRLock()
+	if !ok {
+		t.Fatal("expected second TryRLock to succeed alongside another reader")
+	}
+
+	if _, ok := m.TryLock(); ok {
+		t.Fatal("expected TryLock to fail while readers hold the mutex")
+	}
+
+	r1.Unlock()
+	if _, ok := m.TryLock(); ok {
+		t.Fatal("expected TryLock to still fail with one reader remaining")
+	}
+
+	r2.Unlock()
+	if unlock, ok := m.TryLock(); !ok {
+		t.Fatal("expected TryLock to succeed once all readers unlocked")
+	} else {
+		unlock()
+	}
+}
+
+func TestRWMutexTryUpgradeSoleReader(t *testing.T) {
+	var m RWMutex
+
+	r, ok := m.TryRLock()
+	if !ok {
+		t.Fatal("expected TryRLock to succeed")
+	}
+
+	unlock, ok := r.TryUpgrade()
+	if !ok {
+		t.Fatal("expected TryUpgrade to succeed with no other readers")
+	}
+
+	if _, ok := m.TryRLock(); ok {
+		t.Fatal("expected TryRLock to fail once upgraded to a writer")
+	}
+
+	unlock()
+
+	if _, ok := m.TryLock(); !ok {
+		t.Fatal("expected TryLock to succeed after the upgraded writer unlocked")
+	}
+}
+
+func TestRWMutexTryUpgradeFailsWithOtherReaders(t *testing.T) {
+	var m RWMutex
+
+	r1, ok := m.TryRLock()
+	if !ok {
+		t.Fatal("expected first TryRLock to succeed")
+	}
+	r2, ok := m.TryRLock()
+	if !ok {
+		t.Fatal("expected second TryRLock to succeed")
+	}
+
+	if _, ok := r1.TryUpgrade(); ok {
+		t.Fatal("expected TryUpgrade to fail with another reader present")
+	}
+
+	r1.Unlock()
+	r2.Unlock()
+}
+
+func TestRWMutexConcurrentReadersAndWriters(t *testing.T) {
+	var m RWMutex
+	var shared int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if unlock, ok := m.TryLock(); ok {
+					shared++
+					unlock()
+					continue
+				}
+				if r, ok := m.TryRLock(); ok {
+					r.Unlock()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}